@@ -0,0 +1,394 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Trim removes leading and trailing whitespace (including tabs and newlines) from s.
+func Trim(s string) string {
+	return strings.Trim(s, "\r\n\t ")
+}
+
+// TrimRight removes trailing whitespace (including tabs and newlines) from s.
+func TrimRight(s string) string {
+	return strings.TrimRight(s, "\r\n\t ")
+}
+
+// UniqueInts returns a deduplicated copy of a, optionally sorted in ascending order.
+func UniqueInts(a []int, sorted bool) []int {
+	tmp := make(map[int]bool)
+	uniq := make([]int, 0)
+
+	for _, n := range a {
+		tmp[n] = true
+	}
+	for n := range tmp {
+		uniq = append(uniq, n)
+	}
+	if sorted {
+		sort.Ints(uniq)
+	}
+
+	return uniq
+}
+
+// SepSplit splits s on sep, trimming and dropping empty tokens.
+func SepSplit(s, sep string) []string {
+	filtered := make([]string, 0)
+	parts := strings.Split(s, sep)
+	for _, p := range parts {
+		p = Trim(p)
+		if p != "" {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// CommaSplit is a convenience wrapper around SepSplit(s, ",").
+func CommaSplit(s string) []string {
+	return SepSplit(s, ",")
+}
+
+// tokenizeQuoted is the shared mini POSIX-shell-like splitter behind SepSplitQuoted and
+// SepSplitQuotedE: it tokenizes s on any rune of sep, treating single/double quoted
+// sections as part of the current token and unescaping \", \', \\ and a backslash-escaped
+// separator. It reports whether a quote was left open at the end of s.
+func tokenizeQuoted(s, sep string) (tokens []string, unterminated bool) {
+	filtered := make([]string, 0)
+	var token strings.Builder
+
+	var quote rune
+	escaped := false
+
+	flush := func() {
+		if tok := Trim(token.String()); tok != "" {
+			filtered = append(filtered, tok)
+		}
+		token.Reset()
+	}
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			if r != '"' && r != '\'' && r != '\\' && !strings.ContainsRune(sep, r) {
+				token.WriteRune('\\')
+			}
+			token.WriteRune(r)
+			escaped = false
+
+		case r == '\\':
+			escaped = true
+
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				token.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+
+		case strings.ContainsRune(sep, r):
+			flush()
+
+		default:
+			token.WriteRune(r)
+		}
+	}
+
+	flush()
+
+	return filtered, quote != 0
+}
+
+// SepSplitQuotedE is like SepSplitQuoted, but returns an error instead of silently
+// accepting an unterminated single or double quote.
+func SepSplitQuotedE(s, sep string) ([]string, error) {
+	tokens, unterminated := tokenizeQuoted(s, sep)
+	if unterminated {
+		return nil, fmt.Errorf("unterminated quote in '%s'", s)
+	}
+	return tokens, nil
+}
+
+// SepSplitQuoted splits s on any rune of sep like SepSplit, but treats single and double
+// quoted sections as a single token (so a separator inside quotes doesn't split it) and
+// unescapes \", \', \\ and a backslash-escaped separator. An unterminated quote is not
+// treated as an error here; use SepSplitQuotedE if that should fail instead.
+func SepSplitQuoted(s, sep string) []string {
+	tokens, _ := tokenizeQuoted(s, sep)
+	return tokens
+}
+
+// CommaSplitQuoted is a convenience wrapper around SepSplitQuoted(s, ",").
+func CommaSplitQuoted(s string) []string {
+	return SepSplitQuoted(s, ",")
+}
+
+// ExecOptions controls how ExecContext runs a command.
+type ExecOptions struct {
+	// Timeout, if non-zero, kills the process if it hasn't exited by then.
+	Timeout time.Duration
+	// Dir is the working directory for the command, defaulting to the caller's.
+	Dir string
+	// Env, if non-nil, is appended to the current process environment.
+	Env []string
+	// Stdin, if set, is piped to the process' standard input.
+	Stdin io.Reader
+	// OnStdout, if set, is called with every line of stdout as it's produced.
+	OnStdout func(string)
+	// OnStderr, if set, is called with every line of stderr as it's produced.
+	OnStderr func(string)
+	// KillGrace is how long to wait after SIGTERM before sending SIGKILL.
+	KillGrace time.Duration
+}
+
+// ExecResult carries everything ExecContext captured about a finished (or killed) command.
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
+	Killed   bool
+	// StreamErr is set if reading stdout or stderr failed before the command finished, e.g.
+	// a line longer than the scanner's buffer (bufio.ErrTooLong).
+	StreamErr error
+}
+
+func streamLines(r io.Reader, buf *bytes.Buffer, mu *sync.Mutex, onLine func(string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		mu.Lock()
+		buf.WriteString(line)
+		buf.WriteByte('\n')
+		mu.Unlock()
+
+		if onLine != nil {
+			onLine(line)
+		}
+	}
+	return scanner.Err()
+}
+
+// ExecContext runs bin with args under ctx, streaming stdout/stderr line by line to the
+// optional callbacks in opts while also buffering them for the returned ExecResult. If
+// opts.Timeout is set, it's combined with ctx via context.WithTimeout. On cancellation the
+// process is sent SIGTERM, then SIGKILL after opts.KillGrace (default 5s) if it's still alive.
+func ExecContext(ctx context.Context, bin string, args []string, opts *ExecOptions) (*ExecResult, error) {
+	if opts == nil {
+		opts = &ExecOptions{}
+	}
+
+	path, err := exec.LookPath(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	// send SIGTERM rather than an immediate SIGKILL on cancellation, the escalation below
+	// takes care of SIGKILL once KillGrace has passed.
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	if opts.Dir != "" {
+		cmd.Dir = opts.Dir
+	}
+	if opts.Env != nil {
+		cmd.Env = append(os.Environ(), opts.Env...)
+	}
+	if opts.Stdin != nil {
+		cmd.Stdin = opts.Stdin
+	}
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	killGrace := opts.KillGrace
+	if killGrace <= 0 {
+		killGrace = 5 * time.Second
+	}
+
+	var mu sync.Mutex
+	var stdoutBuf, stderrBuf bytes.Buffer
+
+	started := time.Now()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// escalate SIGTERM -> SIGKILL if ctx is cancelled before the process exits on its own.
+	killed := false
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			if cmd.Process != nil {
+				killed = true
+				cmd.Process.Signal(syscall.SIGTERM)
+				select {
+				case <-done:
+				case <-time.After(killGrace):
+					cmd.Process.Kill()
+				}
+			}
+		case <-done:
+		}
+	}()
+
+	var stdoutErr, stderrErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		stdoutErr = streamLines(stdoutPipe, &stdoutBuf, &mu, opts.OnStdout)
+	}()
+	go func() {
+		defer wg.Done()
+		stderrErr = streamLines(stderrPipe, &stderrBuf, &mu, opts.OnStderr)
+	}()
+	wg.Wait()
+
+	waitErr := cmd.Wait()
+	close(done)
+
+	streamErr := stdoutErr
+	if streamErr == nil {
+		streamErr = stderrErr
+	}
+
+	res := &ExecResult{
+		Stdout:    stdoutBuf.String(),
+		Stderr:    stderrBuf.String(),
+		Duration:  time.Since(started),
+		Killed:    killed,
+		StreamErr: streamErr,
+	}
+
+	if cmd.ProcessState != nil {
+		res.ExitCode = cmd.ProcessState.ExitCode()
+	}
+
+	if killed && (ctx.Err() != nil) {
+		return res, ctx.Err()
+	}
+
+	if waitErr == nil {
+		return res, streamErr
+	}
+
+	return res, waitErr
+}
+
+// ExecSilent runs executable with args and returns its combined, trimmed output without
+// printing anything, regardless of whether it succeeds.
+func ExecSilent(executable string, args []string) (string, error) {
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := exec.Command(path, args...).CombinedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	return Trim(string(raw)), nil
+}
+
+// Exec runs executable with args like ExecSilent, but prints a formatted error to stdout
+// if the command fails.
+func Exec(executable string, args []string) (string, error) {
+	out, err := ExecSilent(executable, args)
+	if err != nil {
+		fmt.Printf("ERROR for '%s %v': %s\n", executable, args, err)
+	}
+	return out, err
+}
+
+// Exists reports whether path exists on the filesystem.
+func Exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ExpandPath resolves ${VAR}/$VAR environment references and a leading ~ or ~user to the
+// relevant home directory, then makes the result absolute.
+func ExpandPath(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	path = os.Expand(path, os.Getenv)
+	if path == "" {
+		return "", nil
+	}
+
+	if path[0] == '~' {
+		rest := path[1:]
+		name := rest
+		if idx := strings.IndexRune(rest, filepath.Separator); idx >= 0 {
+			name = rest[:idx]
+			rest = rest[idx:]
+		} else {
+			rest = ""
+		}
+
+		var usr *user.User
+		var err error
+		if name == "" {
+			usr, err = user.Current()
+		} else {
+			usr, err = user.Lookup(name)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		path = filepath.Join(usr.HomeDir, rest)
+	}
+
+	return filepath.Abs(path)
+}
+
+// ExpandPaths expands environment variables and a leading ~ or ~user in pattern via
+// ExpandPath, then resolves it as a shell glob if it contains any of *, ? or [.
+func ExpandPaths(pattern string) ([]string, error) {
+	expanded, err := ExpandPath(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.ContainsAny(expanded, "*?[") {
+		return filepath.Glob(expanded)
+	}
+
+	return []string{expanded}, nil
+}
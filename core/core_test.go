@@ -2,11 +2,14 @@ package core
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"os"
 	"os/user"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCoreTrim(t *testing.T) {
@@ -223,6 +226,112 @@ func TestCoreExec(t *testing.T) {
 	}
 }
 
+func TestCoreSepSplitQuotedAndCommaSplitQuoted(t *testing.T) {
+	var units = []struct {
+		from string
+		to   []string
+	}{
+		{"foo", []string{"foo"}},
+		{"foo,bar", []string{"foo", "bar"}},
+		{"foo,bar,", []string{"foo", "bar"}},
+		{"foo,,bar,,,,", []string{"foo", "bar"}},
+		{`foo,"bar,baz",qux`, []string{"foo", "bar,baz", "qux"}},
+		{`foo,'bar,baz',qux`, []string{"foo", "bar,baz", "qux"}},
+		{`"foo,bar",'baz,wut'`, []string{"foo,bar", "baz,wut"}},
+		{`foo\,bar,baz`, []string{"foo,bar", "baz"}},
+		{`foo,"bar\"baz",qux`, []string{"foo", `bar"baz`, "qux"}},
+		{`foo,'it\'s',bar`, []string{"foo", "it's", "bar"}},
+		{`foo,bar\\,baz`, []string{"foo", `bar\`, "baz"}},
+	}
+
+	for _, u := range units {
+		if got := SepSplitQuoted(u.from, ","); !sameStrings(got, u.to) {
+			t.Fatalf("expected '%v', got '%v'", u.to, got)
+		} else if got = CommaSplitQuoted(u.from); !sameStrings(got, u.to) {
+			t.Fatalf("expected '%v', got '%v'", u.to, got)
+		}
+	}
+}
+
+func TestCoreSepSplitQuotedEUnterminated(t *testing.T) {
+	var units = []string{
+		`foo,"bar,baz`,
+		`foo,'bar,baz`,
+	}
+
+	for _, from := range units {
+		if _, err := SepSplitQuotedE(from, ","); err == nil {
+			t.Fatalf("expected an error for unterminated quote in '%s', got none", from)
+		}
+	}
+
+	if _, err := SepSplitQuotedE("foo,bar", ","); err != nil {
+		t.Fatalf("expected no error, got '%s'", err)
+	}
+}
+
+func TestCoreExecContextTimeout(t *testing.T) {
+	res, err := ExecContext(context.Background(), "sleep", []string{"5"}, &ExecOptions{
+		Timeout:   50 * time.Millisecond,
+		KillGrace: 10 * time.Millisecond,
+	})
+	if err == nil {
+		t.Fatal("expected a timeout error, got none")
+	} else if res == nil {
+		t.Fatal("expected a non-nil result even on timeout")
+	} else if !res.Killed {
+		t.Fatal("expected the process to be reported as killed")
+	}
+}
+
+func TestCoreExecContextCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	res, err := ExecContext(ctx, "sleep", []string{"5"}, &ExecOptions{KillGrace: 10 * time.Millisecond})
+	if err == nil {
+		t.Fatal("expected a cancellation error, got none")
+	} else if res == nil {
+		t.Fatal("expected a non-nil result even on cancellation")
+	} else if !res.Killed {
+		t.Fatal("expected the process to be reported as killed")
+	}
+}
+
+func TestCoreExecContextStdin(t *testing.T) {
+	res, err := ExecContext(context.Background(), "cat", []string{}, &ExecOptions{
+		Stdin: strings.NewReader("hello world\n"),
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got '%s'", err)
+	} else if got := Trim(res.Stdout); got != "hello world" {
+		t.Fatalf("expected stdout 'hello world', got '%s'", got)
+	}
+}
+
+func TestCoreExecContextSeparateStreams(t *testing.T) {
+	var stdoutLines, stderrLines []string
+
+	res, err := ExecContext(context.Background(), "sh", []string{"-c", "echo out; echo err 1>&2"}, &ExecOptions{
+		OnStdout: func(line string) { stdoutLines = append(stdoutLines, line) },
+		OnStderr: func(line string) { stderrLines = append(stderrLines, line) },
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got '%s'", err)
+	} else if got := Trim(res.Stdout); got != "out" {
+		t.Fatalf("expected stdout 'out', got '%s'", got)
+	} else if got := Trim(res.Stderr); got != "err" {
+		t.Fatalf("expected stderr 'err', got '%s'", got)
+	} else if !sameStrings(stdoutLines, []string{"out"}) {
+		t.Fatalf("expected stdout callback lines '%v', got '%v'", []string{"out"}, stdoutLines)
+	} else if !sameStrings(stderrLines, []string{"err"}) {
+		t.Fatalf("expected stderr callback lines '%v', got '%v'", []string{"err"}, stderrLines)
+	}
+}
+
 func TestCoreExists(t *testing.T) {
 	var units = []struct {
 		what   string
@@ -270,4 +379,56 @@ func TestCoreExpandPath(t *testing.T) {
 			t.Fatalf("expected error '%s', got '%s'", u.err, gotErr.Error())
 		}
 	}
-}
\ No newline at end of file
+}
+
+func TestCoreExpandPathOtherUser(t *testing.T) {
+	root, err := user.Lookup("root")
+	if err != nil {
+		t.Skipf("no root user on this system: %s", err)
+	}
+
+	gotPath, gotErr := ExpandPath("~root")
+	if gotErr != nil {
+		t.Fatalf("expected no error, got '%s'", gotErr)
+	} else if gotPath != root.HomeDir {
+		t.Fatalf("expected path '%s', got '%s'", root.HomeDir, gotPath)
+	}
+}
+
+func TestCoreExpandPathEnvVar(t *testing.T) {
+	home := os.Getenv("HOME")
+	if home == "" {
+		t.Skip("HOME is not set")
+	}
+
+	expected, _ := filepath.Abs(filepath.Join(home, "foo"))
+
+	gotPath, gotErr := ExpandPath("$HOME/foo")
+	if gotErr != nil {
+		t.Fatalf("expected no error, got '%s'", gotErr)
+	} else if gotPath != expected {
+		t.Fatalf("expected path '%s', got '%s'", expected, gotPath)
+	}
+}
+
+func TestCoreExpandPathUnknownUser(t *testing.T) {
+	if _, gotErr := ExpandPath("~thisuserdoesnotexist12345"); gotErr == nil {
+		t.Fatal("expected an error for an unknown user, got none")
+	}
+}
+
+func TestCoreExpandPaths(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.pcap", "b.pcap"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte{}, 0644); err != nil {
+			t.Fatalf("failed to create fixture: %s", err)
+		}
+	}
+
+	got, err := ExpandPaths(filepath.Join(dir, "*.pcap"))
+	if err != nil {
+		t.Fatalf("expected no error, got '%s'", err)
+	} else if len(got) != 2 {
+		t.Fatalf("expected 2 matches, got %d (%v)", len(got), got)
+	}
+}